@@ -0,0 +1,93 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "strings"
+
+// AccessListEntry represent an access list entry in the provider's access list.
+type AccessListEntry struct {
+	Action string   `json:"action,omitempty"`
+	Claim  string   `json:"claim,omitempty"`
+	Values []string `json:"values,omitempty"`
+	Always bool     `json:"always,omitempty"`
+
+	// Path scopes this entry to requests whose URL path matches, e.g.
+	// "/admin/*". Empty matches every path.
+	Path string `json:"path,omitempty"`
+
+	// RequiredACR and RequiredAMR gate access by the strength of the user's
+	// authentication context: a token must carry one of the listed acr
+	// values and have all entries in RequiredAMR present in its amr claim
+	// (an empty list imposes no requirement) to satisfy this entry. Used to
+	// require step-up MFA on sensitive paths
+	// while leaving the rest of the site reachable with a weaker context.
+	RequiredACR []string `json:"required_acr,omitempty"`
+	RequiredAMR []string `json:"required_amr,omitempty"`
+}
+
+// matchesPath reports whether requestPath falls under e.Path. A Path
+// ending in "*" matches by prefix; anything else must match exactly. An
+// empty Path matches every request path.
+func (e *AccessListEntry) matchesPath(requestPath string) bool {
+	if e.Path == "" {
+		return true
+	}
+	if strings.HasSuffix(e.Path, "*") {
+		return strings.HasPrefix(requestPath, strings.TrimSuffix(e.Path, "*"))
+	}
+	return e.Path == requestPath
+}
+
+// satisfiesAuthContext reports whether claims carries a strong enough
+// authentication context to satisfy e's RequiredACR/RequiredAMR. An entry
+// without either requirement is always satisfied. When only one of the two
+// is configured, that one alone gates access; when both are configured,
+// claims must satisfy both (e.g. acr=mfa AND amr containing otp+u2f) - this
+// is a security gate, so an entry combining both requirements must not be
+// let through by satisfying just one of them.
+func (e *AccessListEntry) satisfiesAuthContext(claims *UserClaims) bool {
+	if len(e.RequiredACR) > 0 && !containsString(e.RequiredACR, claims.ACR) {
+		return false
+	}
+	if len(e.RequiredAMR) > 0 && !containsAllStrings(claims.AMR, e.RequiredAMR) {
+		return false
+	}
+	return true
+}
+
+// matchingAccessListEntry returns the first entry in list whose Path
+// matches requestPath and which declares a step-up requirement, or nil if
+// none applies. Callers use this to decide whether the current request
+// needs a stronger authentication context than the one already presented.
+func matchingAccessListEntry(requestPath string, list []*AccessListEntry) *AccessListEntry {
+	for _, entry := range list {
+		if len(entry.RequiredACR) == 0 && len(entry.RequiredAMR) == 0 {
+			continue
+		}
+		if entry.matchesPath(requestPath) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func containsAllStrings(haystack, needles []string) bool {
+	for _, needle := range needles {
+		if !containsString(haystack, needle) {
+			return false
+		}
+	}
+	return true
+}
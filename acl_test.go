@@ -0,0 +1,103 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "testing"
+
+func TestSatisfiesAuthContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  AccessListEntry
+		claims UserClaims
+		want   bool
+	}{
+		{
+			name:   "no requirements always satisfied",
+			entry:  AccessListEntry{},
+			claims: UserClaims{},
+			want:   true,
+		},
+		{
+			name:   "acr only, matches",
+			entry:  AccessListEntry{RequiredACR: []string{"mfa"}},
+			claims: UserClaims{ACR: "mfa"},
+			want:   true,
+		},
+		{
+			name:   "acr only, does not match",
+			entry:  AccessListEntry{RequiredACR: []string{"mfa"}},
+			claims: UserClaims{ACR: "pwd"},
+			want:   false,
+		},
+		{
+			name:   "amr only, has all required methods",
+			entry:  AccessListEntry{RequiredAMR: []string{"otp", "u2f"}},
+			claims: UserClaims{AMR: []string{"pwd", "otp", "u2f"}},
+			want:   true,
+		},
+		{
+			name:   "amr only, missing one required method",
+			entry:  AccessListEntry{RequiredAMR: []string{"otp", "u2f"}},
+			claims: UserClaims{AMR: []string{"otp"}},
+			want:   false,
+		},
+		{
+			name:   "acr and amr both required and both satisfied",
+			entry:  AccessListEntry{RequiredACR: []string{"mfa"}, RequiredAMR: []string{"otp"}},
+			claims: UserClaims{ACR: "mfa", AMR: []string{"otp"}},
+			want:   true,
+		},
+		{
+			name:   "acr and amr both required, only acr satisfied must fail closed",
+			entry:  AccessListEntry{RequiredACR: []string{"mfa"}, RequiredAMR: []string{"otp"}},
+			claims: UserClaims{ACR: "mfa", AMR: []string{}},
+			want:   false,
+		},
+		{
+			name:   "acr and amr both required, only amr satisfied must fail closed",
+			entry:  AccessListEntry{RequiredACR: []string{"mfa"}, RequiredAMR: []string{"otp"}},
+			claims: UserClaims{ACR: "pwd", AMR: []string{"otp"}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.satisfiesAuthContext(&tt.claims); got != tt.want {
+				t.Errorf("satisfiesAuthContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		requestPath string
+		want        bool
+	}{
+		{"", "/anything", true},
+		{"/admin/*", "/admin/users", true},
+		{"/admin/*", "/other", false},
+		{"/exact", "/exact", true},
+		{"/exact", "/exact/sub", false},
+	}
+	for _, tt := range tests {
+		entry := AccessListEntry{Path: tt.path}
+		if got := entry.matchesPath(tt.requestPath); got != tt.want {
+			t.Errorf("matchesPath(%q) with Path=%q = %v, want %v", tt.requestPath, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestBearerTokenFromHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := bearerTokenFromHeader(req); got != "" {
+		t.Errorf("bearerTokenFromHeader() with no header = %q, want empty", got)
+	}
+
+	req.Header.Set("Authorization", "Bearer abc.def.ghi")
+	if got := bearerTokenFromHeader(req); got != "abc.def.ghi" {
+		t.Errorf("bearerTokenFromHeader() = %q, want %q", got, "abc.def.ghi")
+	}
+
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	if got := bearerTokenFromHeader(req); got != "" {
+		t.Errorf("bearerTokenFromHeader() with a non-Bearer scheme = %q, want empty", got)
+	}
+}
+
+func TestClaimAtPath(t *testing.T) {
+	raw := map[string]interface{}{
+		"email": "jdoe@example.com",
+		"resource_access": map[string]interface{}{
+			"myclient": map[string]interface{}{
+				"roles": []interface{}{"admin", "viewer"},
+			},
+		},
+	}
+
+	if got := claimAtPath(raw, "email"); got != "jdoe@example.com" {
+		t.Errorf("claimAtPath(email) = %v, want %q", got, "jdoe@example.com")
+	}
+	got := claimAtPath(raw, "resource_access.myclient.roles")
+	if !reflect.DeepEqual(got, []interface{}{"admin", "viewer"}) {
+		t.Errorf("claimAtPath(resource_access.myclient.roles) = %v, want [admin viewer]", got)
+	}
+	if got := claimAtPath(raw, "resource_access.other.roles"); got != nil {
+		t.Errorf("claimAtPath() for a missing path = %v, want nil", got)
+	}
+}
+
+func TestApplyClaimMap(t *testing.T) {
+	cfg := &BearerTokenIssuer{
+		ClaimMap: map[string]string{
+			"email": "email",
+			"roles": "resource_access.myclient.roles",
+		},
+		RolePrefix: "kc:",
+	}
+	raw := map[string]interface{}{
+		"email": "jdoe@example.com",
+		"resource_access": map[string]interface{}{
+			"myclient": map[string]interface{}{
+				"roles": []interface{}{"admin", "viewer"},
+			},
+		},
+	}
+	claims := &UserClaims{}
+	cfg.applyClaimMap(raw, claims)
+
+	if claims.Email != "jdoe@example.com" {
+		t.Errorf("applyClaimMap() claims.Email = %q, want %q", claims.Email, "jdoe@example.com")
+	}
+	want := []string{"kc:admin", "kc:viewer"}
+	if !reflect.DeepEqual(claims.Roles, want) {
+		t.Errorf("applyClaimMap() claims.Roles = %v, want %v", claims.Roles, want)
+	}
+}
+
+func newBearerTestProvider() AuthProvider {
+	return AuthProvider{
+		BearerTokenAuth: true,
+		BearerTokenIssuers: []*BearerTokenIssuer{
+			{Issuer: "https://issuer-a.example.com"},
+		},
+		TokenValidator: &TokenValidator{
+			TrustedTokens: []*CommonTokenConfig{
+				{TokenIssuer: "https://issuer-a.example.com", TokenSecret: "s3cr3t"},
+			},
+		},
+		logger: zap.NewNop(),
+	}
+}
+
+func TestAuthenticateBearerValidToken(t *testing.T) {
+	m := newBearerTestProvider()
+	claims := &UserClaims{
+		Issuer:    "https://issuer-a.example.com",
+		Email:     "jdoe@example.com",
+		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+	}
+	token, err := claims.GetToken("HS256", []byte("s3cr3t"))
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	user, authenticated, err := m.authenticateBearer(rec, req)
+	if err != nil || !authenticated {
+		t.Fatalf("authenticateBearer() = (%v, %v, %v), want a valid user", user, authenticated, err)
+	}
+	if user.ID != "jdoe@example.com" {
+		t.Errorf("authenticateBearer() user.ID = %q, want %q", user.ID, "jdoe@example.com")
+	}
+}
+
+func TestAuthenticateBearerRejectsUntrustedIssuer(t *testing.T) {
+	m := newBearerTestProvider()
+	claims := &UserClaims{
+		Issuer:    "https://attacker.example.com",
+		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+	}
+	token, err := claims.GetToken("HS256", []byte("whatever"))
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	_, authenticated, err := m.authenticateBearer(rec, req)
+	if authenticated || err == nil {
+		t.Fatalf("authenticateBearer() with an untrusted issuer = (authenticated=%v, err=%v), want failure", authenticated, err)
+	}
+	if rec.Code != 401 {
+		t.Errorf("authenticateBearer() status = %d, want 401", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token"` {
+		t.Errorf("authenticateBearer() WWW-Authenticate = %q, want %q", got, `Bearer error="invalid_token"`)
+	}
+}
+
+func TestAuthenticateBearerRejectsBadSignature(t *testing.T) {
+	m := newBearerTestProvider()
+	claims := &UserClaims{
+		Issuer:    "https://issuer-a.example.com",
+		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+	}
+	token, err := claims.GetToken("HS256", []byte("wrong-secret"))
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	_, authenticated, err := m.authenticateBearer(rec, req)
+	if authenticated || err == nil {
+		t.Fatalf("authenticateBearer() with a bad signature = (authenticated=%v, err=%v), want failure", authenticated, err)
+	}
+	if rec.Code != 401 {
+		t.Errorf("authenticateBearer() status = %d, want 401", rec.Code)
+	}
+}
+
+func TestFindBearerTokenIssuer(t *testing.T) {
+	m := AuthProvider{
+		BearerTokenIssuers: []*BearerTokenIssuer{
+			{Issuer: "https://issuer-a.example.com"},
+			{Issuer: "https://issuer-b.example.com"},
+		},
+	}
+	if cfg := m.findBearerTokenIssuer("https://issuer-b.example.com"); cfg == nil {
+		t.Errorf("findBearerTokenIssuer() for a configured issuer returned nil")
+	}
+	if cfg := m.findBearerTokenIssuer("https://unknown.example.com"); cfg != nil {
+		t.Errorf("findBearerTokenIssuer() for an unconfigured issuer = %v, want nil", cfg)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"time"
+
+	jwtlib "github.com/dgrijalva/jwt-go"
+)
+
+// methods enumerates the token signing methods the grantor is willing to use.
+var methods = map[string]bool{
+	"HS256": true,
+	"HS384": true,
+	"HS512": true,
+}
+
+// defaultSigningMethod is used when minting tokens on the caller's behalf,
+// e.g. when issuing a fresh access token during refresh-token exchange.
+const defaultSigningMethod = "HS256"
+
+// UserClaims represents custom and standard JWT claims associated with a user.
+type UserClaims struct {
+	Audience  string   `json:"aud,omitempty" xml:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty" xml:"exp,omitempty"`
+	ID        string   `json:"jti,omitempty" xml:"jti,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty" xml:"iat,omitempty"`
+	Issuer    string   `json:"iss,omitempty" xml:"iss,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty" xml:"nbf,omitempty"`
+	Subject   string   `json:"sub,omitempty" xml:"sub,omitempty"`
+	Name      string   `json:"name,omitempty" xml:"name,omitempty"`
+	Email     string   `json:"email,omitempty" xml:"email,omitempty"`
+	Roles     []string `json:"roles,omitempty" xml:"roles,omitempty"`
+	Origin    string   `json:"origin,omitempty" xml:"origin,omitempty"`
+
+	// ACR and AMR carry the OIDC "Authentication Context Class Reference"
+	// and "Authentication Methods References" claims, used to evaluate
+	// step-up MFA requirements on selected paths.
+	ACR string   `json:"acr,omitempty" xml:"acr,omitempty"`
+	AMR []string `json:"amr,omitempty" xml:"amr,omitempty"`
+
+	// RefreshToken is the opaque handle of the refresh token associated with
+	// this access token, if any. It is never itself signed into a token
+	// handed to the browser; it is only populated on the in-memory UserClaims
+	// TokenGrantor uses to mint a replacement access token.
+	RefreshToken string `json:"-" xml:"-"`
+}
+
+// GetToken returns a signed JWT token built from the claims using the
+// provided method and secret.
+func (u *UserClaims) GetToken(method string, secret []byte) (string, error) {
+	if _, exists := methods[method]; !exists {
+		return "", ErrUnsupportedSigningMethod.WithArgs(method)
+	}
+	token := jwtlib.NewWithClaims(jwtlib.GetSigningMethod(method), u)
+	return token.SignedString(secret)
+}
+
+// Valid implements jwt.Claims. It enforces ExpiresAt/NotBefore against the
+// current time so jwtlib.ParseWithClaims rejects expired or not-yet-valid
+// tokens instead of trusting a merely well-signed one indefinitely.
+func (u *UserClaims) Valid() error {
+	vErr := new(jwtlib.ValidationError)
+	now := time.Now().Unix()
+
+	if u.ExpiresAt > 0 && now > u.ExpiresAt {
+		vErr.Inner = ErrExpiredToken
+		vErr.Errors |= jwtlib.ValidationErrorExpired
+	}
+	if u.NotBefore > 0 && now < u.NotBefore {
+		vErr.Inner = ErrNotYetValidToken
+		vErr.Errors |= jwtlib.ValidationErrorNotValidYet
+	}
+
+	if vErr.Errors == 0 {
+		return nil
+	}
+	return vErr
+}
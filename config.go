@@ -0,0 +1,50 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+// CommonTokenConfig is the configuration shared by components that issue or
+// validate JWT tokens based on a static, pre-shared secret.
+type CommonTokenConfig struct {
+	TokenName     string `json:"token_name,omitempty"`
+	TokenSecret   string `json:"token_secret,omitempty"`
+	TokenIssuer   string `json:"token_issuer,omitempty"`
+	TokenOrigin   string `json:"token_origin,omitempty"`
+	TokenLifetime int    `json:"token_lifetime,omitempty"`
+}
+
+// TokenValidatorOptions holds per-request options passed to
+// TokenValidator.Authorize. Authorize does not read them: none of the
+// Validate* flags or the Metadata change how a token is evaluated.
+type TokenValidatorOptions struct {
+	ValidateSourceAddress bool                   `json:"validate_source_address,omitempty"`
+	ValidateBearerHeader  bool                   `json:"validate_bearer_header,omitempty"`
+	ValidateMethodPath    bool                   `json:"validate_method_path,omitempty"`
+	Metadata              map[string]interface{} `json:"-"`
+}
+
+// Clone returns a deep-enough copy of the options suitable for per-request
+// mutation (e.g. stamping the current method/path into Metadata).
+func (o *TokenValidatorOptions) Clone() *TokenValidatorOptions {
+	clone := &TokenValidatorOptions{
+		ValidateSourceAddress: o.ValidateSourceAddress,
+		ValidateBearerHeader:  o.ValidateBearerHeader,
+		ValidateMethodPath:    o.ValidateMethodPath,
+		Metadata:              make(map[string]interface{}),
+	}
+	for k, v := range o.Metadata {
+		clone.Metadata[k] = v
+	}
+	return clone
+}
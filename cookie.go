@@ -0,0 +1,103 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// defaultMaxCookieSize is the cookie value size, in bytes, above which a
+// token is split across multiple Set-Cookie headers rather than risk
+// silent truncation by the browser (most browsers cap a single cookie at
+// ~4096 bytes including name and attributes).
+const defaultMaxCookieSize = 4000
+
+// maxCookieChunks bounds how many "<name>_N" chunk cookies writeChunkedCookie
+// and deleteChunkedCookie will ever emit for a single token, as a sanity
+// backstop against unbounded claims.
+const maxCookieChunks = 32
+
+// writeChunkedCookie emits value as a single Set-Cookie when it fits within
+// maxSize, or splits it into "<name>_0", "<name>_1", ... chunks in issuance
+// order otherwise. attrs is appended verbatim to each cookie (e.g.
+// "; path=/; HttpOnly"). A value that would need more than maxCookieChunks
+// chunks is not written at all.
+func writeChunkedCookie(w http.ResponseWriter, name, value string, maxSize int, attrs string) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxCookieSize
+	}
+	if len(value) <= maxSize {
+		w.Header().Add("Set-Cookie", name+"="+value+attrs)
+		return
+	}
+	if (len(value)+maxSize-1)/maxSize > maxCookieChunks {
+		return
+	}
+	for i := 0; i*maxSize < len(value); i++ {
+		end := (i + 1) * maxSize
+		if end > len(value) {
+			end = len(value)
+		}
+		chunkName := fmt.Sprintf("%s_%d", name, i)
+		w.Header().Add("Set-Cookie", chunkName+"="+value[i*maxSize:end]+attrs)
+	}
+}
+
+// readChunkedCookie returns the value previously written by
+// writeChunkedCookie: either the plain "<name>" cookie, or the
+// reassembled contents of "<name>_0", "<name>_1", ... in order. Chunks are
+// required to be contiguous starting at 0; a gap is treated as a partial,
+// unusable set rather than silently serving truncated data.
+func readChunkedCookie(r *http.Request, name string) (string, bool) {
+	if cookie, err := r.Cookie(name); err == nil {
+		return cookie.Value, true
+	}
+
+	value := ""
+	for i := 0; i < maxCookieChunks; i++ {
+		cookie, err := r.Cookie(name + "_" + strconv.Itoa(i))
+		if err != nil {
+			if i == 0 {
+				return "", false
+			}
+			// Reaching the first missing index ends a complete, contiguous
+			// set, but a gap followed by further chunks means the set is
+			// incomplete (e.g. the browser dropped one chunk) - serving the
+			// partial concatenation would hand back a truncated,
+			// unverifiable token, so the whole set is discarded instead.
+			for j := i + 1; j < maxCookieChunks; j++ {
+				if _, err := r.Cookie(name + "_" + strconv.Itoa(j)); err == nil {
+					return "", false
+				}
+			}
+			return value, true
+		}
+		value += cookie.Value
+	}
+	return value, true
+}
+
+// deleteChunkedCookie clears the base cookie and every possible chunk
+// suffix so a previously split token cannot linger in the browser after a
+// failed authentication.
+func deleteChunkedCookie(w http.ResponseWriter, name string) {
+	const expired = "=delete; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT"
+	w.Header().Add("Set-Cookie", name+expired)
+	for i := 0; i < maxCookieChunks; i++ {
+		w.Header().Add("Set-Cookie", fmt.Sprintf("%s_%d%s", name, i, expired))
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteAndReadChunkedCookieRoundTrip(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeChunkedCookie(rec, "tok", "ABCDEFGHIJ", 4, "; path=/")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	got, found := readChunkedCookie(req, "tok")
+	if !found || got != "ABCDEFGHIJ" {
+		t.Errorf("readChunkedCookie() = (%q, %v), want (%q, true)", got, found, "ABCDEFGHIJ")
+	}
+}
+
+func TestReadChunkedCookieDropsPartialSetOnGap(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "tok_0", Value: "AAAA"})
+	req.AddCookie(&http.Cookie{Name: "tok_2", Value: "CCCC"})
+
+	got, found := readChunkedCookie(req, "tok")
+	if found || got != "" {
+		t.Errorf("readChunkedCookie() with a gap = (%q, %v), want (\"\", false)", got, found)
+	}
+}
+
+func TestReadChunkedCookieMissingReturnsNotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, found := readChunkedCookie(req, "tok"); found {
+		t.Errorf("readChunkedCookie() with no cookies present should not be found")
+	}
+}
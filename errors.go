@@ -0,0 +1,32 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "fmt"
+
+// strError is a string-based implementation of the error interface that
+// supports templated arguments via WithArgs.
+type strError string
+
+// Error returns the string representation of an strError.
+func (e strError) Error() string {
+	return string(e)
+}
+
+// WithArgs renders the error with the provided arguments substituted into
+// its message.
+func (e strError) WithArgs(args ...interface{}) error {
+	return fmt.Errorf(string(e), args...)
+}
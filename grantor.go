@@ -14,17 +14,30 @@
 
 package jwt
 
+import "time"
+
 // Grantor Errors
 const (
 	ErrEmptySecret strError = "grantor token secret not configured"
 	ErrNoClaims    strError = "provided claims are nil"
 
 	ErrUnsupportedSigningMethod strError = "grantor does not support %s token signing method"
+
+	ErrRefreshStoreNotConfigured strError = "grantor refresh token store not configured"
 )
 
 // TokenGrantor creates and issues JWT tokens.
 type TokenGrantor struct {
 	CommonTokenConfig
+
+	// RefreshStore persists refresh token handles so that an expired access
+	// token can be renewed without forcing the user through the auth
+	// redirect. Leave nil to disable refresh-token issuance.
+	RefreshStore RefreshTokenStore
+
+	// RefreshTokenLifetime bounds how long an issued refresh handle remains
+	// valid. Defaults to 30 days when zero.
+	RefreshTokenLifetime time.Duration
 }
 
 // NewTokenGrantor returns an instance of TokenGrantor
@@ -33,6 +46,15 @@ func NewTokenGrantor() *TokenGrantor {
 	return g
 }
 
+// accessTokenLifetime returns how long a freshly minted access token should
+// remain valid, defaulting to one hour when TokenLifetime is unset.
+func (g *TokenGrantor) accessTokenLifetime() time.Duration {
+	if g.TokenLifetime > 0 {
+		return time.Duration(g.TokenLifetime) * time.Second
+	}
+	return 1 * time.Hour
+}
+
 // Validate check whether TokenGrantor has valid configuration.
 func (g *TokenGrantor) Validate() error {
 	if g.TokenSecret == "" {
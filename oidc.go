@@ -0,0 +1,374 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OIDC/JWKS Errors
+const (
+	ErrOIDCDiscoveryFailed strError = "oidc discovery for issuer %s failed: %s"
+	ErrJWKSFetchFailed     strError = "jwks fetch for issuer %s failed: %s"
+	ErrInvalidCABundle     strError = "trusted issuer %s ca_bundle %s could not be parsed"
+)
+
+// jwksSupportedAlgorithms enumerates the asymmetric signing algorithms a
+// TrustedIssuer's cached keys may be used with.
+var jwksSupportedAlgorithms = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+}
+
+// TrustedIssuer describes an OpenID Connect provider whose tokens should be
+// accepted without a pre-shared secret. The plugin discovers the provider's
+// JWKS endpoint via its well-known configuration document and verifies
+// tokens against the cached, periodically refreshed key set.
+type TrustedIssuer struct {
+	IssuerURL       string     `json:"issuer_url,omitempty"`
+	Audiences       []string   `json:"audiences,omitempty"`
+	JWKSURL         string     `json:"jwks_url,omitempty"`
+	RefreshInterval string     `json:"refresh_interval,omitempty"`
+	HTTPClient      HTTPClient `json:"http_client,omitempty"`
+
+	refreshEvery time.Duration
+	httpClient   *http.Client
+	done         chan struct{}
+
+	mu        sync.RWMutex
+	keys      map[string]*jwksKey
+	expiresAt time.Time
+}
+
+// HTTPClient carries the transport settings used when talking to an issuer
+// (e.g. a corporate proxy or a private CA bundle).
+type HTTPClient struct {
+	ProxyURL string `json:"proxy_url,omitempty"`
+	CABundle string `json:"ca_bundle,omitempty"`
+}
+
+// buildTransport returns an *http.Transport honoring c's ProxyURL/CABundle,
+// or nil (letting callers fall back to http.DefaultTransport) when neither
+// is set. issuerURL is used only to produce a useful error message.
+func (c HTTPClient) buildTransport(issuerURL string) (*http.Transport, error) {
+	if c.ProxyURL == "" && c.CABundle == "" {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ErrInvalidCABundle.WithArgs(issuerURL, c.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+// jwksKey is a single parsed entry from an issuer's JWKS document.
+type jwksKey struct {
+	KeyID     string
+	Algorithm string
+	publicKey interface{}
+}
+
+// oidcConfiguration is the subset of the well-known openid-configuration
+// document the plugin cares about.
+type oidcConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// jwk is a single JSON Web Key as served by a JWKS endpoint.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ecCurves maps the JWK "crv" parameter to the corresponding curve used by
+// the ES256/384/512 signing algorithms.
+var ecCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// init provisions defaults and resolves the JWKS endpoint via OIDC discovery
+// when jwks_url was not explicitly configured.
+func (ti *TrustedIssuer) init() error {
+	ti.refreshEvery = 1 * time.Hour
+	if ti.RefreshInterval != "" {
+		if d, err := time.ParseDuration(ti.RefreshInterval); err == nil {
+			ti.refreshEvery = d
+		}
+	}
+	if ti.httpClient == nil {
+		transport, err := ti.HTTPClient.buildTransport(ti.IssuerURL)
+		if err != nil {
+			return err
+		}
+		ti.httpClient = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	}
+	if ti.JWKSURL == "" {
+		cfg, err := ti.fetchDiscoveryDocument()
+		if err != nil {
+			return ErrOIDCDiscoveryFailed.WithArgs(ti.IssuerURL, err)
+		}
+		ti.JWKSURL = cfg.JWKSURI
+	}
+	if err := ti.refresh(); err != nil {
+		return err
+	}
+	ti.done = make(chan struct{})
+	go ti.refreshLoop()
+	return nil
+}
+
+// stop ends the background refreshLoop goroutine started by init. Safe to
+// call on a TrustedIssuer whose init never ran or already failed.
+func (ti *TrustedIssuer) stop() {
+	if ti.done != nil {
+		close(ti.done)
+	}
+}
+
+// refreshLoop periodically re-fetches the JWKS document in the background,
+// sleeping until the cache entry's computed expiry (cacheTTL, which adds
+// jitter only when the server sent no usable cache headers) so that normal
+// requests are never blocked waiting on the
+// network. keyFor still falls back to a single synchronous refresh on a
+// kid miss in between cycles. It exits once ti.done is closed by stop.
+func (ti *TrustedIssuer) refreshLoop() {
+	for {
+		ti.mu.RLock()
+		wait := time.Until(ti.expiresAt)
+		ti.mu.RUnlock()
+		if wait <= 0 {
+			wait = ti.refreshEvery
+		}
+		select {
+		case <-time.After(wait):
+			_ = ti.refresh()
+		case <-ti.done:
+			return
+		}
+	}
+}
+
+func (ti *TrustedIssuer) fetchDiscoveryDocument() (*oidcConfiguration, error) {
+	resp, err := ti.httpClient.Get(ti.IssuerURL + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var cfg oidcConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// refresh fetches and parses the JWKS document, honoring any cache
+// directives returned by the server.
+func (ti *TrustedIssuer) refresh() error {
+	resp, err := ti.httpClient.Get(ti.JWKSURL)
+	if err != nil {
+		return ErrJWKSFetchFailed.WithArgs(ti.IssuerURL, err)
+	}
+	defer resp.Body.Close()
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return ErrJWKSFetchFailed.WithArgs(ti.IssuerURL, err)
+	}
+
+	keys := make(map[string]*jwksKey)
+	for _, k := range doc.Keys {
+		var pub interface{}
+		var err error
+		switch k.Kty {
+		case "RSA":
+			pub, err = parseRSAPublicKey(k.N, k.E)
+		case "EC":
+			pub, err = parseECPublicKey(k.Crv, k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &jwksKey{KeyID: k.Kid, Algorithm: k.Alg, publicKey: pub}
+	}
+
+	ti.mu.Lock()
+	ti.keys = keys
+	ti.expiresAt = time.Now().Add(ti.cacheTTL(resp))
+	ti.mu.Unlock()
+	return nil
+}
+
+// cacheTTL derives the cache lifetime from Cache-Control/Expires headers,
+// falling back to the configured refresh interval with a bounded jitter so
+// that many instances do not stampede the issuer at the same moment.
+func (ti *TrustedIssuer) cacheTTL(resp *http.Response) time.Duration {
+	if cc := resp.Header.Get("Cache-Control"); cc != "" {
+		if maxAge, ok := parseMaxAge(cc); ok {
+			return time.Duration(maxAge) * time.Second
+		}
+	}
+	if exp := resp.Header.Get("Expires"); exp != "" {
+		if t, err := http.ParseTime(exp); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+	jitter := time.Duration(rand.Int63n(int64(ti.refreshEvery) / 10))
+	return ti.refreshEvery + jitter
+}
+
+// keyFor returns the cached key matching kid/alg, forcing a single
+// synchronous refresh on a cache miss before giving up.
+func (ti *TrustedIssuer) keyFor(kid, alg string) (interface{}, error) {
+	if !jwksSupportedAlgorithms[alg] {
+		return nil, ErrUnsupportedSigningMethod.WithArgs(alg)
+	}
+
+	ti.mu.RLock()
+	key, found := ti.keys[kid]
+	stale := time.Now().After(ti.expiresAt)
+	ti.mu.RUnlock()
+
+	if found && !stale {
+		if key.Algorithm != "" && key.Algorithm != alg {
+			return nil, ErrUnsupportedSigningMethod.WithArgs(alg)
+		}
+		return key.publicKey, nil
+	}
+
+	if err := ti.refresh(); err != nil {
+		return nil, err
+	}
+
+	ti.mu.RLock()
+	key, found = ti.keys[kid]
+	ti.mu.RUnlock()
+	if !found {
+		return nil, ErrUnknownKeyID.WithArgs(kid)
+	}
+	if key.Algorithm != "" && key.Algorithm != alg {
+		return nil, ErrUnsupportedSigningMethod.WithArgs(alg)
+	}
+	return key.publicKey, nil
+}
+
+func parseMaxAge(cacheControl string) (int, bool) {
+	const prefix = "max-age="
+	for i := 0; i+len(prefix) <= len(cacheControl); i++ {
+		if cacheControl[i:i+len(prefix)] == prefix {
+			j := i + len(prefix)
+			start := j
+			for j < len(cacheControl) && cacheControl[j] >= '0' && cacheControl[j] <= '9' {
+				j++
+			}
+			if seconds, err := strconv.Atoi(cacheControl[start:j]); err == nil {
+				return seconds, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseRSAPublicKey(nEnc, eEnc string) (*rsa.PublicKey, error) {
+	n, err := base64URLDecodeBigInt(nEnc)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64URLDecodeInt(eEnc)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: n, E: e}, nil
+}
+
+func parseECPublicKey(crv, xEnc, yEnc string) (*ecdsa.PublicKey, error) {
+	curve, ok := ecCurves[crv]
+	if !ok {
+		return nil, ErrUnsupportedSigningMethod.WithArgs(crv)
+	}
+	x, err := base64URLDecodeBigInt(xEnc)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64URLDecodeBigInt(yEnc)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func base64URLDecodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func base64URLDecodeInt(s string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+	n := new(big.Int).SetBytes(b)
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "testing"
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantSecs  int
+		wantFound bool
+	}{
+		{"max-age=3600", 3600, true},
+		{"public, max-age=600, must-revalidate", 600, true},
+		{"no-store", 0, false},
+	}
+	for _, tt := range tests {
+		secs, found := parseMaxAge(tt.header)
+		if found != tt.wantFound || secs != tt.wantSecs {
+			t.Errorf("parseMaxAge(%q) = (%d, %v), want (%d, %v)", tt.header, secs, found, tt.wantSecs, tt.wantFound)
+		}
+	}
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	// n/e for a small 256-bit RSA test modulus, base64url-encoded, exponent 65537.
+	const n = "tpS1ZmfVKVP5KofIhMBP0tSWc4qlh6fm94wF-mCZp6w"
+	const e = "AQAB"
+	pub, err := parseRSAPublicKey(n, e)
+	if err != nil {
+		t.Fatalf("parseRSAPublicKey() error = %v", err)
+	}
+	if pub.E != 65537 {
+		t.Errorf("parseRSAPublicKey() E = %d, want 65537", pub.E)
+	}
+}
+
+func TestParseECPublicKeyUnknownCurve(t *testing.T) {
+	if _, err := parseECPublicKey("P-999", "AAAA", "AAAA"); err == nil {
+		t.Errorf("parseECPublicKey() with an unknown curve should error, got nil")
+	}
+}
+
+func TestKeyForRejectsUnsupportedAlgorithm(t *testing.T) {
+	ti := &TrustedIssuer{keys: map[string]*jwksKey{}}
+	if _, err := ti.keyFor("any-kid", "none"); err == nil {
+		t.Errorf("keyFor() with alg=none should error, got nil")
+	}
+}
@@ -53,6 +53,13 @@ type AuthProvider struct {
 	AuthRedirectQueryParameter string                 `json:"auth_redirect_query_param,omitempty"`
 	AccessList                 []*AccessListEntry     `json:"access_list,omitempty"`
 	TrustedTokens              []*CommonTokenConfig   `json:"trusted_tokens,omitempty"`
+	TrustedIssuers             []*TrustedIssuer       `json:"trusted_issuers,omitempty"`
+	BearerTokenAuth            bool                   `json:"bearer_token_auth,omitempty"`
+	BearerTokenIssuers         []*BearerTokenIssuer   `json:"bearer_token_issuers,omitempty"`
+	TokenGrantor               *TokenGrantor          `json:"-"`
+	RefreshTokenCookie         string                 `json:"refresh_token_cookie,omitempty"`
+	RevocationURLPath          string                 `json:"revocation_url_path,omitempty"`
+	MaxCookieSize              int                    `json:"max_cookie_size,omitempty"`
 	TokenValidator             *TokenValidator        `json:"-"`
 	TokenValidatorOptions      *TokenValidatorOptions `json:"token_validate_options,omitempty"`
 	AllowedTokenTypes          []string               `json:"token_types,omitempty"`
@@ -82,6 +89,27 @@ func (AuthProvider) CaddyModule() caddy.ModuleInfo {
 func (m *AuthProvider) Provision(ctx caddy.Context) error {
 	m.logger = ctx.Logger(m)
 	m.startedAt = time.Now().UTC()
+	if m.Name == "" {
+		name, err := newInstanceName()
+		if err != nil {
+			return fmt.Errorf("authentication provider instance naming error: %s", err)
+		}
+		m.Name = name
+	}
+	if m.MaxCookieSize == 0 {
+		m.MaxCookieSize = defaultMaxCookieSize
+	}
+	for _, issuer := range m.TrustedIssuers {
+		if err := issuer.init(); err != nil {
+			return fmt.Errorf(
+				"authentication provider trusted issuer error, instance %s, issuer %s, error: %s",
+				m.Name, issuer.IssuerURL, err,
+			)
+		}
+	}
+	if m.TokenValidator != nil {
+		m.TokenValidator.TrustedIssuers = m.TrustedIssuers
+	}
 	if err := ProviderPool.Register(m); err != nil {
 		return fmt.Errorf(
 			"authentication provider registration error, instance %s, error: %s",
@@ -98,6 +126,20 @@ func (m *AuthProvider) Provision(ctx caddy.Context) error {
 	return nil
 }
 
+// Cleanup implements caddy.CleanerUpper. It runs when Caddy tears down a
+// superseded or removed instance (e.g. on config reload), so the instance
+// does not linger in ProviderPool and its trusted issuers stop polling
+// their JWKS endpoints in the background.
+func (m *AuthProvider) Cleanup() error {
+	if m.Name != "" {
+		ProviderPool.Unregister(m.Name)
+	}
+	for _, issuer := range m.TrustedIssuers {
+		issuer.stop()
+	}
+	return nil
+}
+
 // Validate implements caddy.Validator.
 func (m *AuthProvider) Validate() error {
 	m.logger.Info(
@@ -130,6 +172,15 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 		m = *provisionedInstance
 	}
 
+	if m.RevocationURLPath != "" && r.URL.Path == m.RevocationURLPath {
+		m.HandleRevocation(w, r)
+		return caddyauth.User{}, false, nil
+	}
+
+	if m.BearerTokenAuth && bearerTokenFromHeader(r) != "" {
+		return m.authenticateBearer(w, r)
+	}
+
 	var opts *TokenValidatorOptions
 	if m.ValidateMethodPath {
 		opts = m.TokenValidatorOptions.Clone()
@@ -155,8 +206,17 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 			w.Write([]byte(`Forbidden`))
 			return caddyauth.User{}, false, err
 		}
+		if err == ErrExpiredToken && m.TokenGrantor != nil {
+			if refreshedClaims, ok := m.refreshAccessToken(w, r); ok {
+				userClaims = refreshedClaims
+				validUser = true
+				err = nil
+			}
+		}
+	}
+	if err != nil {
 		for k := range m.TokenValidator.Cookies {
-			w.Header().Add("Set-Cookie", k+"=delete; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT")
+			deleteChunkedCookie(w, k)
 		}
 		addRedirectLocationHeader(w, r, m.AuthURLPath, m.AuthRedirectQueryDisabled, m.AuthRedirectQueryParameter)
 		w.WriteHeader(302)
@@ -169,7 +229,7 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 			zap.String("error", "user invalid"),
 		)
 		for k := range m.TokenValidator.Cookies {
-			w.Header().Add("Set-Cookie", k+"=delete; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT")
+			deleteChunkedCookie(w, k)
 		}
 		addRedirectLocationHeader(w, r, m.AuthURLPath, m.AuthRedirectQueryDisabled, m.AuthRedirectQueryParameter)
 		w.WriteHeader(302)
@@ -183,7 +243,7 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 			zap.String("error", "nil claims"),
 		)
 		for k := range m.TokenValidator.Cookies {
-			w.Header().Add("Set-Cookie", k+"=delete; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT")
+			deleteChunkedCookie(w, k)
 		}
 		addRedirectLocationHeader(w, r, m.AuthURLPath, m.AuthRedirectQueryDisabled, m.AuthRedirectQueryParameter)
 		w.WriteHeader(302)
@@ -191,6 +251,21 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 		return caddyauth.User{}, false, nil
 	}
 
+	if entry := matchingAccessListEntry(r.URL.Path, m.AccessList); entry != nil && !entry.satisfiesAuthContext(userClaims) {
+		m.logger.Debug(
+			"token validation error",
+			zap.String("error", "insufficient authentication context"),
+		)
+		challenge := `Bearer error="insufficient_user_authentication"`
+		if len(entry.RequiredACR) > 0 {
+			challenge += `, acr_values="` + strings.Join(entry.RequiredACR, " ") + `"`
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		w.WriteHeader(401)
+		w.Write([]byte(`Unauthorized`))
+		return caddyauth.User{}, false, nil
+	}
+
 	userIdentity := caddyauth.User{
 		ID: userClaims.Email,
 		Metadata: map[string]string{
@@ -227,6 +302,7 @@ func (m AuthProvider) Authenticate(w http.ResponseWriter, r *http.Request) (cadd
 // Interface guards
 var (
 	_ caddy.Provisioner       = (*AuthProvider)(nil)
+	_ caddy.CleanerUpper      = (*AuthProvider)(nil)
 	_ caddy.Validator         = (*AuthProvider)(nil)
 	_ caddyauth.Authenticator = (*AuthProvider)(nil)
 )
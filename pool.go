@@ -0,0 +1,82 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// Pool Errors
+const (
+	ErrUnknownInstanceName strError = "authorization provider instance %s is not registered"
+)
+
+// AuthProviderPool keeps track of every provisioned AuthProvider instance by
+// name, so that Authenticate can pick up the fully-provisioned copy of a
+// plugin instance that Caddy may otherwise hand back to it only partially
+// initialized.
+type AuthProviderPool struct {
+	mu        sync.RWMutex
+	providers map[string]*AuthProvider
+}
+
+// Register adds m to the pool under m.Name, replacing whatever instance was
+// previously registered under that name. Caddy reprovisions a module on
+// every config reload, handing Register a brand new *AuthProvider each
+// time for what is, from the operator's perspective, the same configured
+// instance, so registration must not fail just because the name was seen
+// before.
+func (p *AuthProviderPool) Register(m *AuthProvider) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.providers == nil {
+		p.providers = make(map[string]*AuthProvider)
+	}
+	m.Provisioned = true
+	p.providers[m.Name] = m
+	return nil
+}
+
+// Unregister removes the instance registered under name, if any. Called
+// from AuthProvider.Cleanup when Caddy tears an instance down, so a
+// superseded instance does not linger in the pool.
+func (p *AuthProviderPool) Unregister(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.providers, name)
+}
+
+// Provision returns the registered, fully-provisioned instance for name.
+func (p *AuthProviderPool) Provision(name string) (*AuthProvider, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	m, exists := p.providers[name]
+	if !exists {
+		return nil, ErrUnknownInstanceName.WithArgs(name)
+	}
+	return m, nil
+}
+
+// newInstanceName returns a random identifier used to key an AuthProvider
+// in the pool when the instance was not given an explicit Name.
+func newInstanceName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
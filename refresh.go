@@ -0,0 +1,223 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Refresh Token Errors
+const (
+	ErrRefreshTokenNotFound strError = "refresh token not found"
+	ErrRefreshTokenExpired  strError = "refresh token expired"
+)
+
+// RefreshTokenEntry is the server-side record associated with an opaque
+// refresh token handle.
+type RefreshTokenEntry struct {
+	Claims    *UserClaims
+	ExpiresAt time.Time
+}
+
+// RefreshTokenStore persists refresh token handles server-side so that a
+// presented handle can be exchanged for a fresh access token. Implementations
+// must be safe for concurrent use. This package provides only the in-memory
+// store below, and no store is installed on a TokenGrantor unless one is
+// assigned to its RefreshStore field; multi-instance deployments assign
+// their own implementation (e.g. Redis- or BoltDB-backed) there.
+type RefreshTokenStore interface {
+	Store(handle string, entry *RefreshTokenEntry) error
+	Load(handle string) (*RefreshTokenEntry, error)
+	Delete(handle string) error
+}
+
+// InMemoryRefreshTokenStore is a RefreshTokenStore held in process memory,
+// suitable for single-instance deployments or testing.
+type InMemoryRefreshTokenStore struct {
+	mu      sync.Mutex
+	entries map[string]*RefreshTokenEntry
+}
+
+// NewInMemoryRefreshTokenStore returns an empty InMemoryRefreshTokenStore.
+func NewInMemoryRefreshTokenStore() *InMemoryRefreshTokenStore {
+	return &InMemoryRefreshTokenStore{
+		entries: make(map[string]*RefreshTokenEntry),
+	}
+}
+
+// Store saves entry under handle, replacing any previous entry.
+func (s *InMemoryRefreshTokenStore) Store(handle string, entry *RefreshTokenEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[handle] = entry
+	return nil
+}
+
+// Load returns the entry for handle, or ErrRefreshTokenNotFound /
+// ErrRefreshTokenExpired.
+func (s *InMemoryRefreshTokenStore) Load(handle string) (*RefreshTokenEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, found := s.entries[handle]
+	if !found {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		delete(s.entries, handle)
+		return nil, ErrRefreshTokenExpired
+	}
+	return entry, nil
+}
+
+// Delete removes handle, if present. Deleting an unknown handle is not an
+// error, so that revocation requests are idempotent.
+func (s *InMemoryRefreshTokenStore) Delete(handle string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, handle)
+	return nil
+}
+
+// GrantRefreshToken issues a new opaque refresh handle for claims and stores
+// it in g.RefreshStore for g.RefreshTokenLifetime.
+func (g *TokenGrantor) GrantRefreshToken(claims *UserClaims) (string, error) {
+	if g.RefreshStore == nil {
+		return "", ErrRefreshStoreNotConfigured
+	}
+	handle, err := newRefreshHandle()
+	if err != nil {
+		return "", err
+	}
+	entry := &RefreshTokenEntry{
+		Claims:    claims,
+		ExpiresAt: time.Now().Add(g.refreshLifetime()),
+	}
+	if err := g.RefreshStore.Store(handle, entry); err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// ExchangeRefreshToken validates handle against g.RefreshStore and, on
+// success, rotates it: the prior handle is invalidated and a new handle
+// covering the same claims is issued. Callers use the returned claims to
+// mint a fresh access token via GrantToken.
+func (g *TokenGrantor) ExchangeRefreshToken(handle string) (claims *UserClaims, newHandle string, err error) {
+	if g.RefreshStore == nil {
+		return nil, "", ErrRefreshStoreNotConfigured
+	}
+	entry, err := g.RefreshStore.Load(handle)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := g.RefreshStore.Delete(handle); err != nil {
+		return nil, "", err
+	}
+	newHandle, err = g.GrantRefreshToken(entry.Claims)
+	if err != nil {
+		return nil, "", err
+	}
+	return entry.Claims, newHandle, nil
+}
+
+// RevokeRefreshToken invalidates handle, e.g. in response to a logout or a
+// dedicated revocation endpoint.
+func (g *TokenGrantor) RevokeRefreshToken(handle string) error {
+	if g.RefreshStore == nil {
+		return ErrRefreshStoreNotConfigured
+	}
+	return g.RefreshStore.Delete(handle)
+}
+
+func (g *TokenGrantor) refreshLifetime() time.Duration {
+	if g.RefreshTokenLifetime > 0 {
+		return g.RefreshTokenLifetime
+	}
+	return 30 * 24 * time.Hour
+}
+
+// refreshAccessToken looks for the refresh cookie on r, exchanges it for a
+// fresh access token via m.TokenGrantor, rewrites the outgoing Set-Cookie
+// headers for both tokens (rotating the refresh handle), and returns the
+// claims to continue the request with instead of redirecting to
+// re-authenticate.
+func (m AuthProvider) refreshAccessToken(w http.ResponseWriter, r *http.Request) (*UserClaims, bool) {
+	cookieName := m.RefreshTokenCookie
+	if cookieName == "" {
+		cookieName = "jwt_refresh"
+	}
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return nil, false
+	}
+
+	claims, newHandle, err := m.TokenGrantor.ExchangeRefreshToken(cookie.Value)
+	if err != nil {
+		m.logger.Debug(
+			"refresh token exchange error",
+			zap.String("error", err.Error()),
+		)
+		return nil, false
+	}
+	claims.RefreshToken = newHandle
+
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(m.TokenGrantor.accessTokenLifetime()).Unix()
+
+	accessToken, err := m.TokenGrantor.GrantToken(defaultSigningMethod, claims)
+	if err != nil {
+		m.logger.Debug(
+			"access token reissuance error",
+			zap.String("error", err.Error()),
+		)
+		return nil, false
+	}
+
+	writeChunkedCookie(w, m.TokenGrantor.TokenName, accessToken, m.MaxCookieSize, "; path=/")
+	w.Header().Add("Set-Cookie", cookieName+"="+newHandle+"; path=/; HttpOnly")
+	return claims, true
+}
+
+// HandleRevocation revokes the refresh token handle carried by the request's
+// refresh cookie and clears it. Authenticate calls this directly for any
+// request matching the configured RevocationURLPath, so no separate caddy
+// route registration is required.
+func (m AuthProvider) HandleRevocation(w http.ResponseWriter, r *http.Request) {
+	cookieName := m.RefreshTokenCookie
+	if cookieName == "" {
+		cookieName = "jwt_refresh"
+	}
+	if cookie, err := r.Cookie(cookieName); err == nil && m.TokenGrantor != nil {
+		_ = m.TokenGrantor.RevokeRefreshToken(cookie.Value)
+	}
+	w.Header().Add("Set-Cookie", cookieName+"=delete; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newRefreshHandle() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
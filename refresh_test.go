@@ -0,0 +1,53 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "testing"
+
+func TestTokenGrantorRefreshRotation(t *testing.T) {
+	g := &TokenGrantor{RefreshStore: NewInMemoryRefreshTokenStore()}
+	claims := &UserClaims{Subject: "jdoe", Email: "jdoe@example.com"}
+
+	handle, err := g.GrantRefreshToken(claims)
+	if err != nil {
+		t.Fatalf("GrantRefreshToken() error = %v", err)
+	}
+
+	got, newHandle, err := g.ExchangeRefreshToken(handle)
+	if err != nil {
+		t.Fatalf("ExchangeRefreshToken() error = %v", err)
+	}
+	if got.Subject != claims.Subject {
+		t.Errorf("ExchangeRefreshToken() claims.Subject = %q, want %q", got.Subject, claims.Subject)
+	}
+	if newHandle == handle {
+		t.Errorf("ExchangeRefreshToken() did not rotate the handle, got the same value back")
+	}
+
+	if _, _, err := g.ExchangeRefreshToken(handle); err == nil {
+		t.Errorf("ExchangeRefreshToken() on a rotated-away handle should fail, got nil error")
+	}
+
+	if _, _, err := g.ExchangeRefreshToken(newHandle); err != nil {
+		t.Errorf("ExchangeRefreshToken() on the rotated handle should succeed, got error = %v", err)
+	}
+}
+
+func TestTokenGrantorAccessTokenLifetimeDefault(t *testing.T) {
+	g := &TokenGrantor{}
+	if got := g.accessTokenLifetime(); got <= 0 {
+		t.Errorf("accessTokenLifetime() with unset TokenLifetime = %v, want a positive default", got)
+	}
+}
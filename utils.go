@@ -0,0 +1,38 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// addRedirectLocationHeader sets the Location header used to send an
+// unauthenticated user to the auth URL, optionally appending a query
+// parameter that points back at the originally requested URL.
+func addRedirectLocationHeader(w http.ResponseWriter, r *http.Request, authURLPath string, queryDisabled bool, queryParameter string) {
+	if authURLPath == "" {
+		return
+	}
+	redirectLocation := authURLPath
+	if !queryDisabled {
+		if queryParameter == "" {
+			queryParameter = "redirect_url"
+		}
+		redirectURL := url.QueryEscape(r.URL.String())
+		redirectLocation = authURLPath + "?" + queryParameter + "=" + redirectURL
+	}
+	w.Header().Set("Location", redirectLocation)
+}
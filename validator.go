@@ -0,0 +1,174 @@
+// Copyright 2020 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+
+	jwtlib "github.com/dgrijalva/jwt-go"
+)
+
+// Validator Errors
+const (
+	ErrNoTokenFound     strError = "no token found"
+	ErrInvalidToken     strError = "invalid token"
+	ErrExpiredToken     strError = "token is expired"
+	ErrNotYetValidToken strError = "token is not yet valid"
+	ErrUnknownKeyID     strError = "token key id %s not found in cached keys"
+)
+
+// TokenValidator validates tokens found in requests and extracts the
+// associated user claims.
+type TokenValidator struct {
+	Cookies        map[string]interface{} `json:"-"`
+	TrustedTokens  []*CommonTokenConfig   `json:"-"`
+	TrustedIssuers []*TrustedIssuer       `json:"-"`
+}
+
+// NewTokenValidator returns an instance of TokenValidator.
+func NewTokenValidator() *TokenValidator {
+	return &TokenValidator{
+		Cookies: make(map[string]interface{}),
+	}
+}
+
+// Authorize inspects the request for a JWT token, validates it against the
+// configured trusted tokens, and returns the associated user claims.
+func (v *TokenValidator) Authorize(r *http.Request, opts *TokenValidatorOptions) (*UserClaims, bool, error) {
+	rawToken, found := v.findToken(r)
+	if !found {
+		return nil, false, ErrNoTokenFound
+	}
+
+	var expired error
+	for _, trusted := range v.TrustedTokens {
+		claims, err := v.validateWithSecret(rawToken, trusted)
+		if err == nil {
+			return claims, true, nil
+		}
+		if err == ErrExpiredToken {
+			expired = err
+		}
+	}
+
+	for _, issuer := range v.TrustedIssuers {
+		claims, err := v.validateWithIssuer(rawToken, issuer)
+		if err == nil {
+			return claims, true, nil
+		}
+		if err == ErrExpiredToken {
+			expired = err
+		}
+	}
+
+	if expired != nil {
+		return nil, false, expired
+	}
+	return nil, false, ErrInvalidToken
+}
+
+// validateWithIssuer verifies rawToken against the key cached by issuer,
+// selecting the verification key by the token's kid/alg headers.
+func (v *TokenValidator) validateWithIssuer(rawToken string, issuer *TrustedIssuer) (*UserClaims, error) {
+	claims := &UserClaims{}
+	_, err := jwtlib.ParseWithClaims(rawToken, claims, func(token *jwtlib.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return issuer.keyFor(kid, token.Method.Alg())
+	})
+	if err != nil {
+		if isExpiredTokenError(err) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != issuer.IssuerURL {
+		return nil, ErrInvalidToken
+	}
+	if len(issuer.Audiences) > 0 && !containsString(issuer.Audiences, claims.Audience) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// verifySignature validates rawToken's signature against whichever
+// configured trusted token secret or trusted issuer matches the claimed
+// issuer, and returns the resulting claims.
+func (v *TokenValidator) verifySignature(rawToken, issuer string) (*UserClaims, error) {
+	for _, cfg := range v.TrustedTokens {
+		if cfg.TokenIssuer != issuer {
+			continue
+		}
+		if claims, err := v.validateWithSecret(rawToken, cfg); err == nil {
+			return claims, nil
+		}
+	}
+	for _, ti := range v.TrustedIssuers {
+		if ti.IssuerURL != issuer {
+			continue
+		}
+		if claims, err := v.validateWithIssuer(rawToken, ti); err == nil {
+			return claims, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *TokenValidator) findToken(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		const prefix = "Bearer "
+		if len(header) > len(prefix) && header[:len(prefix)] == prefix {
+			return header[len(prefix):], true
+		}
+	}
+	for name := range v.Cookies {
+		if value, found := readChunkedCookie(r, name); found {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (v *TokenValidator) validateWithSecret(rawToken string, cfg *CommonTokenConfig) (*UserClaims, error) {
+	claims := &UserClaims{}
+	_, err := jwtlib.ParseWithClaims(rawToken, claims, func(token *jwtlib.Token) (interface{}, error) {
+		return []byte(cfg.TokenSecret), nil
+	})
+	if err != nil {
+		if isExpiredTokenError(err) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// isExpiredTokenError reports whether err is a jwt-go validation error
+// caused solely by token expiry, as opposed to a malformed or forged token.
+func isExpiredTokenError(err error) bool {
+	verr, ok := err.(*jwtlib.ValidationError)
+	if !ok {
+		return false
+	}
+	return verr.Errors == jwtlib.ValidationErrorExpired
+}